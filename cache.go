@@ -0,0 +1,80 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheEntry is the on-disk representation of one cached HTTP response.
+type cacheEntry struct {
+	Body         string    `json:"body"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	FetchedAt    time.Time `json:"fetchedAt"`
+}
+
+// cacheDir returns the directory HTTP responses are cached under, honoring
+// XDG_CACHE_HOME when set and falling back to the OS temp dir otherwise.
+func cacheDir() string {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "price-tracker-cache")
+	}
+	return filepath.Join(os.TempDir(), "price-tracker-cache")
+}
+
+func cachePath(urlStr string) string {
+	sum := sha256.Sum256([]byte(urlStr))
+	return filepath.Join(cacheDir(), hex.EncodeToString(sum[:])+".json")
+}
+
+func loadCacheEntry(urlStr string) (*cacheEntry, bool) {
+	data, err := os.ReadFile(cachePath(urlStr))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+func saveCacheEntry(urlStr string, entry cacheEntry) {
+	if err := os.MkdirAll(cacheDir(), 0755); err != nil {
+		logger.Printf("⚠️ could not create cache dir: %v\n", err)
+		return
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		logger.Printf("⚠️ could not marshal cache entry: %v\n", err)
+		return
+	}
+
+	if err := os.WriteFile(cachePath(urlStr), data, 0644); err != nil {
+		logger.Printf("⚠️ could not write cache entry: %v\n", err)
+	}
+}
+
+func optBool(opts map[string]interface{}, key string) bool {
+	if opts == nil {
+		return false
+	}
+	v, _ := opts[key].(bool)
+	return v
+}
+
+func optInt(opts map[string]interface{}, key string, def int) int {
+	if opts == nil {
+		return def
+	}
+	if v, ok := opts[key].(float64); ok {
+		return int(v)
+	}
+	return def
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// currencySymbols maps a currency symbol to its ISO 4217 code.
+var currencySymbols = map[string]string{
+	"₺":  "TRY",
+	"$":  "USD",
+	"€":  "EUR",
+	"£":  "GBP",
+	"zł": "PLN",
+	"¥":  "JPY",
+}
+
+var currencyCodePattern = regexp.MustCompile(`\b(USD|EUR|GBP|TRY|PLN|JPY)\b`)
+
+// tldCurrency maps a site TLD to the currency customers there are quoted
+// in, used as a last resort when the price text carries no symbol or code.
+var tldCurrency = map[string]string{
+	".com.tr": "TRY",
+	".de":     "EUR",
+	".fr":     "EUR",
+	".co.uk":  "GBP",
+	".pl":     "PLN",
+	".jp":     "JPY",
+}
+
+// detectCurrency figures out the ISO currency code for a price, trying (in
+// order) an explicit hint from structured data (itemprop/JSON-LD/OG), the
+// symbol or ISO code embedded in the price text, and finally the site's
+// TLD. Falls back to TRY, the extension's original market.
+func detectCurrency(priceText, hint, urlStr string) string {
+	if hint != "" {
+		return normalizeCurrencyCode(hint)
+	}
+
+	if code := currencyCodePattern.FindString(priceText); code != "" {
+		return code
+	}
+	for symbol, code := range currencySymbols {
+		if strings.Contains(priceText, symbol) {
+			return code
+		}
+	}
+
+	for tld, code := range tldCurrency {
+		if strings.Contains(urlStr, tld) {
+			return code
+		}
+	}
+
+	return "TRY"
+}
+
+func normalizeCurrencyCode(code string) string {
+	code = strings.TrimSpace(code)
+	if mapped, ok := currencySymbols[code]; ok {
+		return mapped
+	}
+	return strings.ToUpper(code)
+}
+
+// commaIsDecimal reports whether, for the given currency, a comma in a
+// price string is a decimal separator (as in "1.234,56 TRY") rather than a
+// thousands separator (as in "1,299.99 USD").
+func commaIsDecimal(currency string) bool {
+	switch currency {
+	case "USD", "GBP", "JPY":
+		return false
+	default:
+		return true
+	}
+}
+
+var priceNumberPattern = regexp.MustCompile(`[\d.,]*\d`)
+
+// extractPriceFromTextLocale parses the first number out of text, choosing
+// comma-vs-dot decimal semantics based on currency instead of always
+// assuming the Turkish "1.234,56" format.
+func extractPriceFromTextLocale(text, currency string) float64 {
+	cleaned := stripCurrencyNoise(text)
+
+	match := priceNumberPattern.FindString(cleaned)
+	if match == "" {
+		return 0
+	}
+
+	var normalized string
+	if commaIsDecimal(currency) {
+		normalized = strings.ReplaceAll(match, ".", "")
+		normalized = strings.ReplaceAll(normalized, ",", ".")
+	} else {
+		normalized = strings.ReplaceAll(match, ",", "")
+	}
+
+	var price float64
+	fmt.Sscanf(normalized, "%f", &price)
+	return price
+}
+
+func stripCurrencyNoise(text string) string {
+	replacer := strings.NewReplacer(
+		"₺", "", "TL", "", "TRY", "",
+		"$", "", "USD", "",
+		"€", "", "EUR", "",
+		"£", "", "GBP", "",
+		"zł", "", "PLN", "",
+		"¥", "", "JPY", "",
+	)
+	return strings.TrimSpace(replacer.Replace(text))
+}
@@ -0,0 +1,47 @@
+package main
+
+import "testing"
+
+func TestExtractPriceFromTextLocale(t *testing.T) {
+	cases := []struct {
+		name     string
+		text     string
+		currency string
+		want     float64
+	}{
+		{"TRY comma decimal", "1.234,56 TL", "TRY", 1234.56},
+		{"USD comma thousands", "1,299.99", "USD", 1299.99},
+		{"GBP comma thousands", "£1,050.00", "GBP", 1050.00},
+		{"JPY comma thousands", "¥12,345", "JPY", 12345},
+		{"EUR comma decimal", "89,90 €", "EUR", 89.90},
+		{"plain integer", "500", "USD", 500},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := extractPriceFromTextLocale(tc.text, tc.currency)
+			if got != tc.want {
+				t.Errorf("extractPriceFromTextLocale(%q, %q) = %v, want %v", tc.text, tc.currency, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCommaIsDecimal(t *testing.T) {
+	cases := []struct {
+		currency string
+		want     bool
+	}{
+		{"USD", false},
+		{"GBP", false},
+		{"JPY", false},
+		{"TRY", true},
+		{"EUR", true},
+	}
+
+	for _, tc := range cases {
+		if got := commaIsDecimal(tc.currency); got != tc.want {
+			t.Errorf("commaIsDecimal(%q) = %v, want %v", tc.currency, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// historyDB is the process-wide price history store. It is nil if opening
+// the database failed, in which case history-backed actions degrade to
+// returning an error instead of crashing the backend.
+var historyDB *sql.DB
+
+func init() {
+	db, err := openHistoryDB()
+	if err != nil {
+		logger.Printf("⚠️ could not open price history db: %v\n", err)
+		return
+	}
+	historyDB = db
+}
+
+func historyDBPath() string {
+	return filepath.Join(cacheDir(), "price-history.db")
+}
+
+func openHistoryDB() (*sql.DB, error) {
+	if err := os.MkdirAll(cacheDir(), 0755); err != nil {
+		return nil, err
+	}
+
+	// checkMultipleProducts's worker pool can have several goroutines
+	// calling recordPrice concurrently. SQLite only allows one writer at a
+	// time, so without a busy timeout a losing writer gets SQLITE_BUSY
+	// immediately instead of waiting its turn, and recordPrice's caller
+	// only logs that error — silently dropping the history row. Capping
+	// the pool at one connection plus a generous busy_timeout serializes
+	// writers through database/sql itself instead of racing on the lock.
+	db, err := sql.Open("sqlite", historyDBPath()+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS price_history (
+		url        TEXT NOT NULL,
+		fetched_at INTEGER NOT NULL,
+		price      REAL NOT NULL,
+		currency   TEXT NOT NULL,
+		name       TEXT NOT NULL
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// PricePoint is one recorded observation of a product's price.
+type PricePoint struct {
+	FetchedAt int64   `json:"fetchedAt"`
+	Price     float64 `json:"price"`
+	Currency  string  `json:"currency"`
+	Name      string  `json:"name"`
+}
+
+// PriceDrop describes a URL whose latest price has fallen significantly
+// below its recent moving average.
+type PriceDrop struct {
+	URL         string  `json:"url"`
+	LatestPrice float64 `json:"latestPrice"`
+	MovingAvg   float64 `json:"movingAvg"`
+	DropPercent float64 `json:"dropPercent"`
+}
+
+func recordPrice(product *ProductInfo) error {
+	if historyDB == nil {
+		return fmt.Errorf("price history db unavailable")
+	}
+	_, err := historyDB.Exec(
+		`INSERT INTO price_history (url, fetched_at, price, currency, name) VALUES (?, ?, ?, ?, ?)`,
+		product.URL, time.Now().Unix(), product.Price, product.Currency, product.Name,
+	)
+	return err
+}
+
+func getHistory(urlStr string) ([]PricePoint, error) {
+	if historyDB == nil {
+		return nil, fmt.Errorf("price history db unavailable")
+	}
+
+	rows, err := historyDB.Query(
+		`SELECT fetched_at, price, currency, name FROM price_history WHERE url = ? ORDER BY fetched_at ASC`,
+		urlStr,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []PricePoint
+	for rows.Next() {
+		var p PricePoint
+		if err := rows.Scan(&p.FetchedAt, &p.Price, &p.Currency, &p.Name); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+// detectDrops returns every tracked URL whose latest price has dropped at
+// least minDropPercent versus its moving average over the last windowDays.
+func detectDrops(minDropPercent float64, windowDays int) ([]PriceDrop, error) {
+	if historyDB == nil {
+		return nil, fmt.Errorf("price history db unavailable")
+	}
+
+	rows, err := historyDB.Query(`SELECT DISTINCT url FROM price_history`)
+	if err != nil {
+		return nil, err
+	}
+	var urls []string
+	for rows.Next() {
+		var u string
+		if err := rows.Scan(&u); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		urls = append(urls, u)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -windowDays).Unix()
+
+	var drops []PriceDrop
+	for _, u := range urls {
+		history, err := getHistory(u)
+		if err != nil || len(history) == 0 {
+			continue
+		}
+		latest := history[len(history)-1]
+
+		var sum float64
+		var count int
+		for _, p := range history[:len(history)-1] {
+			if p.FetchedAt >= cutoff {
+				sum += p.Price
+				count++
+			}
+		}
+		if count == 0 {
+			continue
+		}
+		avg := sum / float64(count)
+		if avg <= 0 {
+			continue
+		}
+
+		dropPercent := (avg - latest.Price) / avg * 100
+		if dropPercent >= minDropPercent {
+			drops = append(drops, PriceDrop{
+				URL:         u,
+				LatestPrice: latest.Price,
+				MovingAvg:   avg,
+				DropPercent: dropPercent,
+			})
+		}
+	}
+
+	return drops, nil
+}
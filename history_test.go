@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDetectDropsExcludesLatestFromAverage(t *testing.T) {
+	if historyDB == nil {
+		t.Skip("price history db unavailable")
+	}
+
+	url := "https://example.com/detect-drops-test-product"
+	t.Cleanup(func() {
+		historyDB.Exec(`DELETE FROM price_history WHERE url = ?`, url)
+	})
+
+	now := time.Now().Unix()
+	insert := func(daysAgo int, price float64) {
+		historyDB.Exec(
+			`INSERT INTO price_history (url, fetched_at, price, currency, name) VALUES (?, ?, ?, ?, ?)`,
+			url, now-int64(daysAgo*86400), price, "USD", "Test Product",
+		)
+	}
+	insert(3, 100.0)
+	insert(0, 85.0) // a real 15% drop vs the prior point
+
+	drops, err := detectDrops(10, 7)
+	if err != nil {
+		t.Fatalf("detectDrops: %v", err)
+	}
+
+	for _, d := range drops {
+		if d.URL == url {
+			return
+		}
+	}
+	t.Fatalf("expected a drop for %q, got none in %+v", url, drops)
+}
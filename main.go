@@ -1,15 +1,17 @@
 package main
 
 import (
+	"context"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
-	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/net/html"
@@ -25,6 +27,7 @@ type Request struct {
 
 type Response struct {
 	Success bool                   `json:"success"`
+	Action  string                 `json:"action,omitempty"`
 	Data    map[string]interface{} `json:"data,omitempty"`
 	Error   string                 `json:"error,omitempty"`
 }
@@ -118,7 +121,7 @@ func handleRequest(req Request) Response {
 		if req.URL == "" {
 			return Response{Success: false, Error: "URL is required"}
 		}
-		product, err := fetchProductPrice(req.URL)
+		product, err := fetchProductPrice(context.Background(), req.URL, req.Options)
 		if err != nil {
 			return Response{Success: false, Error: err.Error()}
 		}
@@ -133,7 +136,7 @@ func handleRequest(req Request) Response {
 		if req.URL == "" {
 			return Response{Success: false, Error: "URL is required"}
 		}
-		html, err := fetchHTML(req.URL)
+		html, err := fetchHTML(context.Background(), req.URL, req.Options)
 		if err != nil {
 			return Response{Success: false, Error: err.Error()}
 		}
@@ -159,11 +162,30 @@ func handleRequest(req Request) Response {
 			},
 		}
 
+	case "extractMany":
+		if req.URL == "" || req.Data == nil || req.Data["selectors"] == nil {
+			return Response{Success: false, Error: "URL and selectors are required"}
+		}
+		selectors, ok := req.Data["selectors"].(map[string]interface{})
+		if !ok {
+			return Response{Success: false, Error: "selectors must be an object"}
+		}
+		results, err := extractMany(req.URL, selectors)
+		if err != nil {
+			return Response{Success: false, Error: err.Error()}
+		}
+		return Response{
+			Success: true,
+			Data: map[string]interface{}{
+				"results": results,
+			},
+		}
+
 	case "checkMultipleProducts":
 		if req.Data == nil || req.Data["products"] == nil {
 			return Response{Success: false, Error: "Products array is required"}
 		}
-		results := checkMultipleProducts(req.Data["products"].([]interface{}))
+		results := checkMultipleProducts(req.Data["products"].([]interface{}), req.Options)
 		return Response{
 			Success: true,
 			Data: map[string]interface{}{
@@ -171,17 +193,78 @@ func handleRequest(req Request) Response {
 			},
 		}
 
+	case "recordPrice":
+		if req.Data == nil || req.Data["url"] == nil || req.Data["price"] == nil {
+			return Response{Success: false, Error: "url and price are required"}
+		}
+		product := productFromData(req.Data)
+		if err := recordPrice(product); err != nil {
+			return Response{Success: false, Error: err.Error()}
+		}
+		return Response{Success: true}
+
+	case "getHistory":
+		if req.URL == "" {
+			return Response{Success: false, Error: "URL is required"}
+		}
+		history, err := getHistory(req.URL)
+		if err != nil {
+			return Response{Success: false, Error: err.Error()}
+		}
+		return Response{
+			Success: true,
+			Data: map[string]interface{}{
+				"history": history,
+			},
+		}
+
+	case "detectDrops":
+		minDropPercent := 10.0
+		windowDays := 7
+		if req.Options != nil {
+			if v, ok := req.Options["minDropPercent"].(float64); ok && v > 0 {
+				minDropPercent = v
+			}
+			if v, ok := req.Options["windowDays"].(float64); ok && v > 0 {
+				windowDays = int(v)
+			}
+		}
+		drops, err := detectDrops(minDropPercent, windowDays)
+		if err != nil {
+			return Response{Success: false, Error: err.Error()}
+		}
+		return Response{
+			Success: true,
+			Data: map[string]interface{}{
+				"drops": drops,
+			},
+		}
+
+	case "registerShop":
+		if req.Data == nil || req.Data["domains"] == nil {
+			return Response{Success: false, Error: "domains are required"}
+		}
+		if err := registerDynamicShop(req.Data); err != nil {
+			return Response{Success: false, Error: err.Error()}
+		}
+		return Response{
+			Success: true,
+			Data: map[string]interface{}{
+				"registered": true,
+			},
+		}
+
 	default:
 		return Response{Success: false, Error: fmt.Sprintf("Unknown action: %s", req.Action)}
 	}
 }
 
 // Fetch product price from URL
-func fetchProductPrice(urlStr string) (*ProductInfo, error) {
+func fetchProductPrice(ctx context.Context, urlStr string, opts map[string]interface{}) (*ProductInfo, error) {
 	logger.Printf("🔍 Fetching price from: %s\n", urlStr)
 
 	// Fetch HTML
-	html, err := fetchHTML(urlStr)
+	html, err := fetchHTML(ctx, urlStr, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -199,14 +282,35 @@ func fetchProductPrice(urlStr string) (*ProductInfo, error) {
 	}
 
 	logger.Printf("✅ Product found: %s - %.2f %s\n", product.Name, product.Price, product.Currency)
+
+	if err := recordPrice(product); err != nil {
+		logger.Printf("⚠️ could not record price history: %v\n", err)
+	}
+
 	return product, nil
 }
 
+// productFromData builds a ProductInfo out of a recordPrice request's raw
+// Data map.
+func productFromData(data map[string]interface{}) *ProductInfo {
+	product := &ProductInfo{}
+	product.URL, _ = data["url"].(string)
+	product.Name, _ = data["name"].(string)
+	product.Currency, _ = data["currency"].(string)
+	if product.Currency == "" {
+		product.Currency = "TRY"
+	}
+	if price, ok := data["price"].(float64); ok {
+		product.Price = price
+	}
+	return product
+}
+
 // Extract product with custom selector
 func extractWithSelector(urlStr, selector string) (*ProductInfo, error) {
 	logger.Printf("🎯 Extracting with selector: %s from %s\n", selector, urlStr)
 
-	html, err := fetchHTML(urlStr)
+	html, err := fetchHTML(context.Background(), urlStr, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -216,14 +320,14 @@ func extractWithSelector(urlStr, selector string) (*ProductInfo, error) {
 		return nil, err
 	}
 
-	// Simple selector matching (you can use goquery for better CSS selector support)
-	text := extractTextBySelector(doc, selector)
-	if text == "" {
-		return nil, fmt.Errorf("selector not found or empty")
+	matches, err := selectorMatches(doc, selector)
+	if err != nil {
+		return nil, err
 	}
 
 	// Extract price from text
-	price := extractPriceFromText(text)
+	currency := detectCurrency(matches[0], "", urlStr)
+	price := extractPriceFromTextLocale(matches[0], currency)
 	if price == 0 {
 		return nil, fmt.Errorf("no price found in selected element")
 	}
@@ -234,7 +338,7 @@ func extractWithSelector(urlStr, selector string) (*ProductInfo, error) {
 	return &ProductInfo{
 		Name:       name,
 		Price:      price,
-		Currency:   "TRY",
+		Currency:   currency,
 		URL:        urlStr,
 		Site:       getSiteName(urlStr),
 		Confidence: 0.95,
@@ -242,51 +346,30 @@ func extractWithSelector(urlStr, selector string) (*ProductInfo, error) {
 	}, nil
 }
 
-// Check multiple products concurrently
-func checkMultipleProducts(products []interface{}) []map[string]interface{} {
-	logger.Printf("🔄 Checking %d products concurrently\n", len(products))
-
-	results := make([]map[string]interface{}, len(products))
-	done := make(chan bool)
-
-	for i, p := range products {
-		go func(index int, product interface{}) {
-			productMap := product.(map[string]interface{})
-			url := productMap["url"].(string)
-
-			result := map[string]interface{}{
-				"index":   index,
-				"url":     url,
-				"success": false,
+// Fetch HTML from URL, serving a cached body on a 304 and writing any new
+// response back to the cache. opts may set "maxAgeSec" (skip the request
+// entirely if the cached copy is fresher than this) and "noCache" (ignore
+// the cache altogether).
+func fetchHTML(ctx context.Context, urlStr string, opts map[string]interface{}) (string, error) {
+	noCache := optBool(opts, "noCache")
+	maxAgeSec := optInt(opts, "maxAgeSec", 0)
+
+	var cached *cacheEntry
+	if !noCache {
+		if entry, ok := loadCacheEntry(urlStr); ok {
+			cached = entry
+			if maxAgeSec > 0 && time.Since(entry.FetchedAt) < time.Duration(maxAgeSec)*time.Second {
+				logger.Printf("🗄️ Cache hit (fresh) for %s\n", urlStr)
+				return entry.Body, nil
 			}
-
-			if prod, err := fetchProductPrice(url); err == nil {
-				result["success"] = true
-				result["product"] = prod
-			} else {
-				result["error"] = err.Error()
-			}
-
-			results[index] = result
-			done <- true
-		}(i, p)
-	}
-
-	// Wait for all goroutines
-	for i := 0; i < len(products); i++ {
-		<-done
+		}
 	}
 
-	return results
-}
-
-// Fetch HTML from URL
-func fetchHTML(urlStr string) (string, error) {
 	client := &http.Client{
 		Timeout: 15 * time.Second,
 	}
 
-	req, err := http.NewRequest("GET", urlStr, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
 	if err != nil {
 		return "", err
 	}
@@ -295,12 +378,28 @@ func fetchHTML(urlStr string) (string, error) {
 	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
 	req.Header.Set("Accept-Language", "tr-TR,tr;q=0.9,en-US;q=0.8,en;q=0.7")
 
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
 	resp, err := client.Do(req)
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		logger.Printf("🗄️ Cache hit (304) for %s\n", urlStr)
+		cached.FetchedAt = time.Now()
+		saveCacheEntry(urlStr, *cached)
+		return cached.Body, nil
+	}
+
 	if resp.StatusCode != 200 {
 		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
 	}
@@ -310,7 +409,15 @@ func fetchHTML(urlStr string) (string, error) {
 		return "", err
 	}
 
-	return string(body), nil
+	entry := cacheEntry{
+		Body:         string(body),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    time.Now(),
+	}
+	saveCacheEntry(urlStr, entry)
+
+	return entry.Body, nil
 }
 
 // Parse HTML string to document
@@ -320,34 +427,57 @@ func parseHTML(r io.Reader) (*html.Node, error) {
 
 // Extract product info from HTML document
 func extractProductInfo(doc *html.Node, urlStr string) *ProductInfo {
-	// Try schema.org first
+	// Gather every extractor's best guess — including a site-specific
+	// adapter, which knows the real selectors instead of guessing from
+	// generic "price" class names — and keep the most confident one:
+	// JSON-LD (0.95) > microdata (0.85) > OpenGraph (0.8) > shop adapter
+	// (0.9) > class-pattern heuristics (0.7).
+	var candidates []*ProductInfo
+	if u, err := url.Parse(urlStr); err == nil {
+		if product, err := shopManager.Extract(doc, u); err == nil {
+			candidates = append(candidates, product)
+		}
+	}
+	if product := extractFromJSONLD(doc, urlStr); product != nil {
+		candidates = append(candidates, product)
+	}
 	if product := extractFromSchema(doc, urlStr); product != nil {
-		return product
+		candidates = append(candidates, product)
+	}
+	if product := extractFromOpenGraph(doc, urlStr); product != nil {
+		candidates = append(candidates, product)
 	}
-
-	// Try common price patterns
 	if product := extractWithPatterns(doc, urlStr); product != nil {
-		return product
+		candidates = append(candidates, product)
 	}
 
-	return nil
+	return bestCandidate(candidates)
 }
 
 // Extract from schema.org microdata
 func extractFromSchema(doc *html.Node, urlStr string) *ProductInfo {
-	var price float64
-	var name string
+	var priceText, name, currencyHint string
 
 	var findSchema func(*html.Node)
 	findSchema = func(n *html.Node) {
 		if n.Type == html.ElementNode {
 			for _, attr := range n.Attr {
-				if attr.Key == "itemprop" {
-					if attr.Val == "price" {
-						price = extractPriceFromText(getTextContent(n))
-					} else if attr.Val == "name" {
-						name = getTextContent(n)
+				if attr.Key != "itemprop" {
+					continue
+				}
+				switch attr.Val {
+				case "price":
+					priceText = getTextContent(n)
+					if priceText == "" {
+						priceText = getAttr(n, "content")
 					}
+				case "priceCurrency":
+					currencyHint = getAttr(n, "content")
+					if currencyHint == "" {
+						currencyHint = getTextContent(n)
+					}
+				case "name":
+					name = getTextContent(n)
 				}
 			}
 		}
@@ -359,41 +489,45 @@ func extractFromSchema(doc *html.Node, urlStr string) *ProductInfo {
 
 	findSchema(doc)
 
-	if price > 0 && name != "" {
-		return &ProductInfo{
-			Name:       name,
-			Price:      price,
-			Currency:   "TRY",
-			URL:        urlStr,
-			Site:       getSiteName(urlStr),
-			Confidence: 0.85,
-			Method:     "schema.org-go",
-		}
+	if priceText == "" || name == "" {
+		return nil
 	}
 
-	return nil
+	currency := detectCurrency(priceText, currencyHint, urlStr)
+	price := extractPriceFromTextLocale(priceText, currency)
+	if price == 0 {
+		return nil
+	}
+
+	return &ProductInfo{
+		Name:       name,
+		Price:      price,
+		Currency:   currency,
+		URL:        urlStr,
+		Site:       getSiteName(urlStr),
+		Confidence: 0.85,
+		Method:     "schema.org-go",
+	}
 }
 
 // Extract with common patterns
 func extractWithPatterns(doc *html.Node, urlStr string) *ProductInfo {
 	// Find elements with price-related classes
-	var price float64
-	var name string
+	var priceText, name string
 
 	var findPrice func(*html.Node)
 	findPrice = func(n *html.Node) {
 		if n.Type == html.ElementNode {
 			class := getAttr(n, "class")
 			if strings.Contains(class, "price") || strings.Contains(class, "fiyat") {
-				text := getTextContent(n)
-				if p := extractPriceFromText(text); p > 0 {
-					price = p
+				if text := getTextContent(n); text != "" {
+					priceText = text
 				}
 			}
 		}
 
 		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			if price == 0 {
+			if priceText == "" {
 				findPrice(c)
 			}
 		}
@@ -416,46 +550,25 @@ func extractWithPatterns(doc *html.Node, urlStr string) *ProductInfo {
 
 	findName(doc)
 
-	if price > 0 && name != "" {
-		return &ProductInfo{
-			Name:       name,
-			Price:      price,
-			Currency:   "TRY",
-			URL:        urlStr,
-			Site:       getSiteName(urlStr),
-			Confidence: 0.7,
-			Method:     "pattern-go",
-		}
+	if priceText == "" || name == "" {
+		return nil
 	}
 
-	return nil
-}
-
-// Extract price from text
-func extractPriceFromText(text string) float64 {
-	// Remove currency symbols and clean
-	text = strings.ReplaceAll(text, "₺", "")
-	text = strings.ReplaceAll(text, "TL", "")
-	text = strings.ReplaceAll(text, "TRY", "")
-	text = strings.TrimSpace(text)
-
-	// Match number patterns
-	re := regexp.MustCompile(`(\d{1,3}(?:[.,]\d{3})*(?:[.,]\d{1,2})?|\d+(?:[.,]\d{1,2})?)`)
-	matches := re.FindStringSubmatch(text)
-	if len(matches) == 0 {
-		return 0
+	currency := detectCurrency(priceText, "", urlStr)
+	price := extractPriceFromTextLocale(priceText, currency)
+	if price == 0 {
+		return nil
 	}
 
-	priceStr := matches[1]
-	// Handle Turkish format: 1.234,56 -> 1234.56
-	if strings.Contains(priceStr, ",") {
-		priceStr = strings.ReplaceAll(priceStr, ".", "")
-		priceStr = strings.ReplaceAll(priceStr, ",", ".")
+	return &ProductInfo{
+		Name:       name,
+		Price:      price,
+		Currency:   currency,
+		URL:        urlStr,
+		Site:       getSiteName(urlStr),
+		Confidence: 0.7,
+		Method:     "pattern-go",
 	}
-
-	var price float64
-	fmt.Sscanf(priceStr, "%f", &price)
-	return price
 }
 
 // Extract product name from document
@@ -476,39 +589,6 @@ func extractProductName(doc *html.Node) string {
 	return name
 }
 
-// Extract text by selector (basic implementation)
-func extractTextBySelector(doc *html.Node, selector string) string {
-	// This is a simplified selector matcher
-	// For production, use github.com/PuerkitoBio/goquery
-	var result string
-	var find func(*html.Node)
-	find = func(n *html.Node) {
-		if n.Type == html.ElementNode {
-			class := getAttr(n, "class")
-			id := getAttr(n, "id")
-
-			// Simple class/id matching
-			if strings.Contains(selector, ".") && strings.Contains(class, strings.TrimPrefix(selector, ".")) {
-				result = getTextContent(n)
-				return
-			}
-			if strings.Contains(selector, "#") && strings.Contains(id, strings.TrimPrefix(selector, "#")) {
-				result = getTextContent(n)
-				return
-			}
-		}
-
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			if result == "" {
-				find(c)
-			}
-		}
-	}
-
-	find(doc)
-	return result
-}
-
 // Get text content of node
 func getTextContent(n *html.Node) string {
 	if n.Type == html.TextNode {
@@ -541,6 +621,13 @@ func getSiteName(urlStr string) string {
 	return "Unknown"
 }
 
+// stdoutMu serializes writes to os.Stdout. Worker-pool goroutines (see
+// checkMultipleProducts's progress messages) can call sendResponse
+// concurrently with each other and with the main loop, and the
+// length-prefix/body pair must reach Firefox as one atomic unit or the wire
+// framing desyncs.
+var stdoutMu sync.Mutex
+
 // Send response to Firefox
 func sendResponse(resp Response) {
 	responseBytes, err := json.Marshal(resp)
@@ -551,6 +638,9 @@ func sendResponse(resp Response) {
 
 	length := uint32(len(responseBytes))
 
+	stdoutMu.Lock()
+	defer stdoutMu.Unlock()
+
 	// Write message length
 	if err := binary.Write(os.Stdout, binary.LittleEndian, length); err != nil {
 		logger.Printf("❌ Error writing response length: %v\n", err)
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// parseSelectorExpr splits a selector expression like "img.main@src" into
+// its CSS selector and an optional attribute to read instead of text
+// content.
+func parseSelectorExpr(expr string) (selector, attr string) {
+	if idx := strings.LastIndex(expr, "@"); idx > 0 {
+		return expr[:idx], expr[idx+1:]
+	}
+	return expr, ""
+}
+
+// selectorMatches runs a (possibly attribute-qualified) CSS selector
+// against doc via goquery and returns the text or attribute value of every
+// matching element.
+func selectorMatches(doc *html.Node, expr string) ([]string, error) {
+	gdoc := goquery.NewDocumentFromNode(doc)
+	selector, attr := parseSelectorExpr(expr)
+
+	sel := gdoc.Find(selector)
+	if sel.Length() == 0 {
+		return nil, fmt.Errorf("selector %q matched no elements", selector)
+	}
+
+	matches := make([]string, 0, sel.Length())
+	sel.Each(func(_ int, s *goquery.Selection) {
+		if attr != "" {
+			if val, ok := s.Attr(attr); ok {
+				matches = append(matches, strings.TrimSpace(val))
+			}
+			return
+		}
+		matches = append(matches, strings.TrimSpace(s.Text()))
+	})
+
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("selector %q matched no %s", selector, attrDescription(attr))
+	}
+	return matches, nil
+}
+
+func attrDescription(attr string) string {
+	if attr == "" {
+		return "text"
+	}
+	return "attribute " + attr
+}
+
+// extractMany fetches urlStr and resolves a named set of selector
+// expressions against it, e.g. {"title": "h1", "image": "img.main@src"}.
+// Selectors that match nothing are omitted from the result rather than
+// failing the whole call.
+func extractMany(urlStr string, selectors map[string]interface{}) (map[string][]string, error) {
+	body, err := fetchHTML(context.Background(), urlStr, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := parseHTML(strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string][]string, len(selectors))
+	for field, exprRaw := range selectors {
+		expr, ok := exprRaw.(string)
+		if !ok || expr == "" {
+			continue
+		}
+
+		matches, err := selectorMatches(doc, expr)
+		if err != nil {
+			logger.Printf("⚠️ extractMany: %v\n", err)
+			continue
+		}
+		results[field] = matches
+	}
+
+	return results, nil
+}
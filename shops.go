@@ -0,0 +1,233 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Shop knows how to pull product info out of one retailer's pages.
+type Shop interface {
+	// Domains returns the hostnames (without "www.") this Shop handles.
+	Domains() []string
+	Extract(doc *html.Node, u *url.URL) (*ProductInfo, error)
+}
+
+// Manager dispatches extraction to the Shop registered for a URL's host.
+type Manager struct {
+	shops map[string]Shop
+}
+
+// NewManager builds a Manager pre-registered with the built-in adapters.
+func NewManager() *Manager {
+	m := &Manager{shops: make(map[string]Shop)}
+	m.register(&trendyolShop{})
+	m.register(&hepsiburadaShop{})
+	m.register(&amazonTRShop{})
+	m.register(&n11Shop{})
+	return m
+}
+
+func (m *Manager) register(shop Shop) {
+	for _, domain := range shop.Domains() {
+		m.shops[domain] = shop
+	}
+}
+
+func (m *Manager) shopFor(host string) (Shop, bool) {
+	shop, ok := m.shops[strings.TrimPrefix(host, "www.")]
+	return shop, ok
+}
+
+// Extract dispatches to the Shop registered for u's host, if any.
+func (m *Manager) Extract(doc *html.Node, u *url.URL) (*ProductInfo, error) {
+	shop, ok := m.shopFor(u.Host)
+	if !ok {
+		return nil, fmt.Errorf("no shop registered for host %q", u.Host)
+	}
+	return shop.Extract(doc, u)
+}
+
+// shopManager is the process-wide registry, seeded with the built-in
+// adapters and extended at runtime via the registerShop action.
+var shopManager = NewManager()
+
+// findByClassHints locates the first element whose class attribute contains
+// one of priceClasses and extracts a price from its text. It backs the
+// built-in per-site adapters below, which mostly differ in class naming.
+func findByClassHints(doc *html.Node, priceClasses []string, site, urlStr string) (*ProductInfo, error) {
+	var priceText string
+	var find func(*html.Node)
+	find = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			class := getAttr(n, "class")
+			for _, hint := range priceClasses {
+				if strings.Contains(class, hint) {
+					if text := getTextContent(n); text != "" {
+						priceText = text
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if priceText == "" {
+				find(c)
+			}
+		}
+	}
+	find(doc)
+
+	name := extractProductName(doc)
+	if priceText == "" || name == "" {
+		return nil, fmt.Errorf("%s: could not locate price/name", site)
+	}
+
+	currency := detectCurrency(priceText, "", urlStr)
+	price := extractPriceFromTextLocale(priceText, currency)
+	if price == 0 {
+		return nil, fmt.Errorf("%s: could not parse price from %q", site, priceText)
+	}
+
+	return &ProductInfo{
+		Name:       name,
+		Price:      price,
+		Currency:   currency,
+		Site:       site,
+		Confidence: 0.9,
+		Method:     "shop-adapter-go",
+	}, nil
+}
+
+type trendyolShop struct{}
+
+func (trendyolShop) Domains() []string { return []string{"trendyol.com"} }
+
+func (trendyolShop) Extract(doc *html.Node, u *url.URL) (*ProductInfo, error) {
+	product, err := findByClassHints(doc, []string{"prc-dsc", "pr-bx-w"}, "Trendyol", u.String())
+	if product != nil {
+		product.URL = u.String()
+	}
+	return product, err
+}
+
+type hepsiburadaShop struct{}
+
+func (hepsiburadaShop) Domains() []string { return []string{"hepsiburada.com"} }
+
+func (hepsiburadaShop) Extract(doc *html.Node, u *url.URL) (*ProductInfo, error) {
+	product, err := findByClassHints(doc, []string{"product-price", "price-amount"}, "Hepsiburada", u.String())
+	if product != nil {
+		product.URL = u.String()
+	}
+	return product, err
+}
+
+type amazonTRShop struct{}
+
+func (amazonTRShop) Domains() []string { return []string{"amazon.com.tr"} }
+
+func (amazonTRShop) Extract(doc *html.Node, u *url.URL) (*ProductInfo, error) {
+	product, err := findByClassHints(doc, []string{"a-price-whole", "a-offscreen"}, "Amazon.tr", u.String())
+	if product != nil {
+		product.URL = u.String()
+	}
+	return product, err
+}
+
+type n11Shop struct{}
+
+func (n11Shop) Domains() []string { return []string{"n11.com"} }
+
+func (n11Shop) Extract(doc *html.Node, u *url.URL) (*ProductInfo, error) {
+	product, err := findByClassHints(doc, []string{"newPrice", "priceNumber"}, "N11", u.String())
+	if product != nil {
+		product.URL = u.String()
+	}
+	return product, err
+}
+
+// dynamicShop is a Shop taught to the backend at runtime via registerShop,
+// using the same goquery-based selector matching as extractWithSelector.
+type dynamicShop struct {
+	domains       []string
+	priceSelector string
+	nameSelector  string
+	site          string
+}
+
+func (d *dynamicShop) Domains() []string { return d.domains }
+
+func (d *dynamicShop) Extract(doc *html.Node, u *url.URL) (*ProductInfo, error) {
+	priceMatches, err := selectorMatches(doc, d.priceSelector)
+	if err != nil || len(priceMatches) == 0 {
+		return nil, fmt.Errorf("%s: no price found for selector %q", d.site, d.priceSelector)
+	}
+	priceText := priceMatches[0]
+
+	currency := detectCurrency(priceText, "", u.String())
+	price := extractPriceFromTextLocale(priceText, currency)
+	if price == 0 {
+		return nil, fmt.Errorf("%s: no price found for selector %q", d.site, d.priceSelector)
+	}
+
+	name := ""
+	if d.nameSelector != "" {
+		if nameMatches, err := selectorMatches(doc, d.nameSelector); err == nil && len(nameMatches) > 0 {
+			name = nameMatches[0]
+		}
+	}
+	if name == "" {
+		name = extractProductName(doc)
+	}
+
+	return &ProductInfo{
+		Name:       name,
+		Price:      price,
+		Currency:   currency,
+		URL:        u.String(),
+		Site:       d.site,
+		Confidence: 0.9,
+		Method:     "registered-shop-go",
+	}, nil
+}
+
+// registerDynamicShop validates a registerShop request payload and adds the
+// resulting adapter to shopManager.
+func registerDynamicShop(data map[string]interface{}) error {
+	domainsRaw, ok := data["domains"].([]interface{})
+	if !ok || len(domainsRaw) == 0 {
+		return fmt.Errorf("domains must be a non-empty array")
+	}
+
+	domains := make([]string, 0, len(domainsRaw))
+	for _, d := range domainsRaw {
+		domain, ok := d.(string)
+		if !ok || domain == "" {
+			return fmt.Errorf("domains must be non-empty strings")
+		}
+		domains = append(domains, domain)
+	}
+
+	priceSelector, _ := data["priceSelector"].(string)
+	if priceSelector == "" {
+		return fmt.Errorf("priceSelector is required")
+	}
+	nameSelector, _ := data["nameSelector"].(string)
+
+	site, _ := data["site"].(string)
+	if site == "" {
+		site = domains[0]
+	}
+
+	shopManager.register(&dynamicShop{
+		domains:       domains,
+		priceSelector: priceSelector,
+		nameSelector:  nameSelector,
+		site:          site,
+	})
+
+	logger.Printf("🧩 Registered shop %q for domains %v\n", site, domains)
+	return nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"golang.org/x/net/html"
+)
+
+// bestCandidate returns the candidate with the highest Confidence, or nil
+// if candidates is empty.
+func bestCandidate(candidates []*ProductInfo) *ProductInfo {
+	var best *ProductInfo
+	for _, c := range candidates {
+		if best == nil || c.Confidence > best.Confidence {
+			best = c
+		}
+	}
+	return best
+}
+
+// extractFromJSONLD looks for <script type="application/ld+json"> blocks
+// and returns the first schema.org Product they describe.
+func extractFromJSONLD(doc *html.Node, urlStr string) *ProductInfo {
+	var scripts []*html.Node
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "script" && getAttr(n, "type") == "application/ld+json" {
+			scripts = append(scripts, n)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	for _, script := range scripts {
+		if product := parseJSONLDProduct(getTextContent(script), urlStr); product != nil {
+			return product
+		}
+	}
+	return nil
+}
+
+// parseJSONLDProduct parses a single JSON-LD payload, which may be a lone
+// object, an array of objects, or an object wrapping an "@graph", and
+// returns the first node typed "Product".
+func parseJSONLDProduct(raw, urlStr string) *ProductInfo {
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+		return nil
+	}
+
+	for _, node := range flattenJSONLD(parsed) {
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if t, _ := obj["@type"].(string); t != "Product" {
+			continue
+		}
+
+		name, _ := obj["name"].(string)
+		priceText, currencyHint := jsonLDOffer(obj["offers"])
+		if name == "" || priceText == "" {
+			continue
+		}
+
+		currency := detectCurrency(priceText, currencyHint, urlStr)
+		price := extractPriceFromTextLocale(priceText, currency)
+		if price == 0 {
+			continue
+		}
+
+		image, _ := obj["image"].(string)
+
+		return &ProductInfo{
+			Name:       name,
+			Price:      price,
+			Currency:   currency,
+			URL:        urlStr,
+			Site:       getSiteName(urlStr),
+			Image:      image,
+			Confidence: 0.95,
+			Method:     "json-ld-go",
+		}
+	}
+	return nil
+}
+
+// flattenJSONLD normalizes a parsed JSON-LD document into a flat list of
+// nodes, unwrapping arrays and "@graph" containers.
+func flattenJSONLD(v interface{}) []interface{} {
+	switch t := v.(type) {
+	case []interface{}:
+		var out []interface{}
+		for _, item := range t {
+			out = append(out, flattenJSONLD(item)...)
+		}
+		return out
+	case map[string]interface{}:
+		if graph, ok := t["@graph"]; ok {
+			return flattenJSONLD(graph)
+		}
+		return []interface{}{t}
+	default:
+		return nil
+	}
+}
+
+// jsonLDOffer pulls the raw price text and currency hint out of a
+// Product's "offers", which per schema.org may be a single Offer object or
+// an array of them.
+func jsonLDOffer(v interface{}) (priceText, currencyHint string) {
+	offer := v
+	if arr, ok := v.([]interface{}); ok && len(arr) > 0 {
+		offer = arr[0]
+	}
+
+	obj, ok := offer.(map[string]interface{})
+	if !ok {
+		return "", ""
+	}
+
+	switch p := obj["price"].(type) {
+	case float64:
+		priceText = strconv.FormatFloat(p, 'f', -1, 64)
+	case string:
+		priceText = p
+	}
+	currencyHint, _ = obj["priceCurrency"].(string)
+	return priceText, currencyHint
+}
+
+// extractFromOpenGraph reads og:title/og:image and the product:price:*
+// meta tags that SPA product pages embed for link previews.
+func extractFromOpenGraph(doc *html.Node, urlStr string) *ProductInfo {
+	meta := collectMetaTags(doc)
+
+	name := meta["og:title"]
+	priceText := meta["product:price:amount"]
+	if name == "" || priceText == "" {
+		return nil
+	}
+
+	currency := detectCurrency(priceText, meta["product:price:currency"], urlStr)
+	price := extractPriceFromTextLocale(priceText, currency)
+	if price == 0 {
+		return nil
+	}
+
+	return &ProductInfo{
+		Name:       name,
+		Price:      price,
+		Currency:   currency,
+		URL:        urlStr,
+		Site:       getSiteName(urlStr),
+		Image:      meta["og:image"],
+		Confidence: 0.8,
+		Method:     "opengraph-go",
+	}
+}
+
+// collectMetaTags indexes every <meta property="..."> / <meta name="...">
+// tag in doc by its property/name.
+func collectMetaTags(doc *html.Node) map[string]string {
+	meta := make(map[string]string)
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "meta" {
+			key := getAttr(n, "property")
+			if key == "" {
+				key = getAttr(n, "name")
+			}
+			if key != "" {
+				meta[key] = getAttr(n, "content")
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return meta
+}
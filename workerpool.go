@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultMaxConcurrency = 8
+	maxFetchAttempts      = 4
+	baseBackoff           = 200 * time.Millisecond
+)
+
+// checkOptions holds the tunables read from req.Options for
+// checkMultipleProducts.
+type checkOptions struct {
+	maxConcurrency int
+	deadline       time.Duration
+}
+
+func parseCheckOptions(opts map[string]interface{}) checkOptions {
+	co := checkOptions{maxConcurrency: defaultMaxConcurrency}
+	if opts == nil {
+		return co
+	}
+	if v, ok := opts["maxConcurrency"].(float64); ok && v > 0 {
+		co.maxConcurrency = int(v)
+	}
+	if v, ok := opts["timeoutMs"].(float64); ok && v > 0 {
+		co.deadline = time.Duration(v) * time.Millisecond
+	}
+	return co
+}
+
+// hostLockSet serializes access per-host so a burst of URLs on the same
+// retailer doesn't hit it concurrently and trip rate limits.
+type hostLockSet struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func newHostLockSet() *hostLockSet {
+	return &hostLockSet{locks: make(map[string]*sync.Mutex)}
+}
+
+func (h *hostLockSet) lock(rawURL string) (unlock func()) {
+	host := hostOf(rawURL)
+
+	h.mu.Lock()
+	l, ok := h.locks[host]
+	if !ok {
+		l = &sync.Mutex{}
+		h.locks[host] = l
+	}
+	h.mu.Unlock()
+
+	l.Lock()
+	return l.Unlock
+}
+
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.Host
+}
+
+// isRetryable reports whether err is a transient fetch failure (timeout,
+// 429, or 5xx) worth retrying rather than a permanent one.
+func isRetryable(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "HTTP 429") || strings.HasPrefix(msg, "HTTP 5")
+}
+
+// fetchProductPriceWithRetry wraps fetchProductPrice with exponential
+// backoff and jitter on transient errors, bailing out early once ctx is
+// done.
+func fetchProductPriceWithRetry(ctx context.Context, urlStr string, opts map[string]interface{}) (*ProductInfo, error) {
+	var lastErr error
+	for attempt := 0; attempt < maxFetchAttempts; attempt++ {
+		if attempt > 0 {
+			backoff := time.Duration(math.Pow(2, float64(attempt))) * baseBackoff
+			jitter := time.Duration(rand.Int63n(int64(backoff)))
+			select {
+			case <-time.After(backoff + jitter):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		product, err := fetchProductPrice(ctx, urlStr, opts)
+		if err == nil {
+			return product, nil
+		}
+		lastErr = err
+		if !isRetryable(err) {
+			return nil, err
+		}
+	}
+	return nil, lastErr
+}
+
+// checkMultipleProducts fetches prices for a batch of URLs through a
+// bounded worker pool: at most opts.maxConcurrency in flight at once, at
+// most one in flight per host, with retries on transient errors and a
+// "progress" message pushed after each completion.
+func checkMultipleProducts(products []interface{}, opts map[string]interface{}) []map[string]interface{} {
+	co := parseCheckOptions(opts)
+	logger.Printf("🔄 Checking %d products (maxConcurrency=%d)\n", len(products), co.maxConcurrency)
+
+	ctx := context.Background()
+	if co.deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, co.deadline)
+		defer cancel()
+	}
+
+	results := make([]map[string]interface{}, len(products))
+	hostLocks := newHostLockSet()
+	sem := make(chan struct{}, co.maxConcurrency)
+
+	var completed int32
+	var wg sync.WaitGroup
+
+	for i, p := range products {
+		productMap, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		productURL, _ := productMap["url"].(string)
+
+		wg.Add(1)
+		go func(index int, productURL string) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			unlock := hostLocks.lock(productURL)
+			defer unlock()
+
+			result := map[string]interface{}{
+				"index":   index,
+				"url":     productURL,
+				"success": false,
+			}
+
+			if product, err := fetchProductPriceWithRetry(ctx, productURL, opts); err == nil {
+				result["success"] = true
+				result["product"] = product
+			} else {
+				result["error"] = err.Error()
+			}
+			results[index] = result
+
+			done := atomic.AddInt32(&completed, 1)
+			sendResponse(Response{
+				Success: true,
+				Action:  "progress",
+				Data: map[string]interface{}{
+					"completed": int(done),
+					"total":     len(products),
+				},
+			})
+		}(i, productURL)
+	}
+
+	wg.Wait()
+	return results
+}